@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/riferrei/srclient"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// confluentMagicByte is the leading byte of the Confluent wire format:
+// magic byte + 4-byte big-endian schema ID, followed by the encoded payload.
+const confluentMagicByte = 0x0
+
+// CodecType selects how a topic's Kafka payload is framed on the wire.
+type CodecType string
+
+const (
+	CodecJSON     CodecType = "json"
+	CodecAvro     CodecType = "avro"
+	CodecProtobuf CodecType = "protobuf"
+)
+
+// DecodedMessage is the result of decoding a Kafka message value, regardless
+// of the wire codec that produced it. Consumers route on the topic plus
+// SchemaVersion (see routeForMessage) and read fields from Payload.
+type DecodedMessage struct {
+	Payload       map[string]interface{}
+	Codec         CodecType
+	SchemaID      int
+	SchemaVersion int
+}
+
+var (
+	topicCodecs   = map[string]CodecType{}
+	protoMessages = map[string]proto.Message{}
+	protoMu       sync.RWMutex
+
+	schemaRegistry     *srclient.SchemaRegistryClient
+	schemaRegistryOnce sync.Once
+)
+
+func init() {
+	for _, pair := range strings.Split(getEnv("KAFKA_TOPIC_CODECS", ""), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		topicCodecs[strings.TrimSpace(parts[0])] = CodecType(strings.ToLower(strings.TrimSpace(parts[1])))
+	}
+}
+
+// RegisterProtoMessage associates a topic with a concrete proto.Message type
+// used to decode/encode its Protobuf-framed payloads. Call during package
+// init for each topic configured with codec=protobuf.
+func RegisterProtoMessage(topic string, msg proto.Message) {
+	protoMu.Lock()
+	defer protoMu.Unlock()
+	protoMessages[topic] = msg
+}
+
+func codecForTopic(topic string) CodecType {
+	if codec, ok := topicCodecs[topic]; ok {
+		return codec
+	}
+	return CodecJSON
+}
+
+func getSchemaRegistry() *srclient.SchemaRegistryClient {
+	schemaRegistryOnce.Do(func() {
+		url := getEnv("SCHEMA_REGISTRY_URL", "")
+		if url == "" {
+			return
+		}
+		schemaRegistry = srclient.CreateSchemaRegistryClient(url)
+	})
+	return schemaRegistry
+}
+
+// decodeKafkaValue decodes a raw Kafka message value according to the codec
+// configured for topic, returning a generic payload map plus the schema
+// metadata (when the wire format carries one) so callers can route on it.
+func decodeKafkaValue(topic string, value []byte) (*DecodedMessage, error) {
+	codec := codecForTopic(topic)
+	if codec == CodecJSON {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(value, &payload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json payload: %w", err)
+		}
+		return &DecodedMessage{Payload: payload, Codec: CodecJSON}, nil
+	}
+
+	if len(value) < 5 || value[0] != confluentMagicByte {
+		return nil, fmt.Errorf("payload for topic %s is missing the confluent wire-format header", topic)
+	}
+	schemaID := int(binary.BigEndian.Uint32(value[1:5]))
+	body := value[5:]
+
+	registry := getSchemaRegistry()
+	if registry == nil {
+		return nil, fmt.Errorf("SCHEMA_REGISTRY_URL is not configured but topic %s requires schema %d", topic, schemaID)
+	}
+	schema, err := registry.GetSchema(schemaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema %d from registry: %w", schemaID, err)
+	}
+
+	var payload map[string]interface{}
+	switch codec {
+	case CodecAvro:
+		avroCodec, err := goavro.NewCodec(schema.Schema())
+		if err != nil {
+			return nil, fmt.Errorf("failed to build avro codec for schema %d: %w", schemaID, err)
+		}
+		native, _, err := avroCodec.NativeFromBinary(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode avro payload for schema %d: %w", schemaID, err)
+		}
+		asJSON, err := json.Marshal(native)
+		if err != nil {
+			return nil, fmt.Errorf("failed to normalize avro payload for schema %d: %w", schemaID, err)
+		}
+		if err := json.Unmarshal(asJSON, &payload); err != nil {
+			return nil, fmt.Errorf("failed to normalize avro payload for schema %d: %w", schemaID, err)
+		}
+	case CodecProtobuf:
+		protoMu.RLock()
+		msgType, ok := protoMessages[topic]
+		protoMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("no proto message registered for topic %s; call RegisterProtoMessage", topic)
+		}
+		msg := proto.Clone(msgType)
+		proto.Reset(msg)
+		if err := proto.Unmarshal(body, msg); err != nil {
+			return nil, fmt.Errorf("failed to decode protobuf payload for schema %d: %w", schemaID, err)
+		}
+		asJSON, err := protojson.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to normalize protobuf payload for schema %d: %w", schemaID, err)
+		}
+		if err := json.Unmarshal(asJSON, &payload); err != nil {
+			return nil, fmt.Errorf("failed to normalize protobuf payload for schema %d: %w", schemaID, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown codec %q for topic %s", codec, topic)
+	}
+
+	version := 0
+	if schema.Version() != nil {
+		version = *schema.Version()
+	}
+	return &DecodedMessage{
+		Payload:       payload,
+		Codec:         codec,
+		SchemaID:      schemaID,
+		SchemaVersion: version,
+	}, nil
+}
+
+// encodeForSubject encodes a JSON outbox payload for publication to subject's
+// topic, looking up the latest schema registered for subject and prepending
+// the Confluent wire-format header when the topic's codec requires one. It
+// does not register a schema: a subject with no registered schema is an
+// error.
+func encodeForSubject(subject, topic string, jsonPayload []byte) ([]byte, error) {
+	codec := codecForTopic(topic)
+	if codec == CodecJSON {
+		return jsonPayload, nil
+	}
+
+	registry := getSchemaRegistry()
+	if registry == nil {
+		return nil, fmt.Errorf("SCHEMA_REGISTRY_URL is not configured but topic %s requires schema encoding", topic)
+	}
+
+	schema, err := registry.GetLatestSchema(subject)
+	if err != nil {
+		logger.Warn("no registered schema found for subject, registering from payload shape is not supported for binary codecs",
+			zap.String("subject", subject), zap.Error(err))
+		return nil, fmt.Errorf("failed to resolve latest schema for subject %s: %w", subject, err)
+	}
+
+	var body []byte
+	switch codec {
+	case CodecAvro:
+		var native interface{}
+		if err := json.Unmarshal(jsonPayload, &native); err != nil {
+			return nil, fmt.Errorf("failed to parse outbox payload as json: %w", err)
+		}
+		avroCodec, err := goavro.NewCodec(schema.Schema())
+		if err != nil {
+			return nil, fmt.Errorf("failed to build avro codec for subject %s: %w", subject, err)
+		}
+		body, err = avroCodec.BinaryFromNative(nil, native)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode avro payload for subject %s: %w", subject, err)
+		}
+	case CodecProtobuf:
+		protoMu.RLock()
+		msgType, ok := protoMessages[topic]
+		protoMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("no proto message registered for topic %s; call RegisterProtoMessage", topic)
+		}
+		msg := proto.Clone(msgType)
+		proto.Reset(msg)
+		if err := protojson.Unmarshal(jsonPayload, msg); err != nil {
+			return nil, fmt.Errorf("failed to parse outbox payload into %T: %w", msgType, err)
+		}
+		body, err = proto.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode protobuf payload for subject %s: %w", subject, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown codec %q for topic %s", codec, topic)
+	}
+
+	header := make([]byte, 5)
+	header[0] = confluentMagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(schema.ID()))
+	return append(header, body...), nil
+}
@@ -2,101 +2,72 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
-	"github.com/segmentio/kafka-go"
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
-func startKafkaConsumer(ctx context.Context) {
-	logger.Info("Starting Kafka consumer")
+// messageRoutes maps a (topic, schema version) pair to the action a decoded
+// message should trigger. A zero schema version matches any version, which
+// keeps plain-JSON topics (no registry involved) working unchanged.
+type routeKey struct {
+	topic         string
+	schemaVersion int
+}
 
-	kafkaBrokers := getEnv("KAFKA_BROKERS", "localhost:9092")
-	consumerGroup := getEnv("KAFKA_CONSUMER_GROUP_GRPC", "grpc-service-group")
+var messageRoutes = map[routeKey]string{
+	{topic: "resource.created", schemaVersion: 0}: "process_new_resource",
+	{topic: "resource.updated", schemaVersion: 0}: "reprocess_resource",
+	{topic: "resource.deleted", schemaVersion: 0}: "cleanup_resource",
+}
 
-	// Topics to consume
-	topics := []string{
-		"resource.created",
-		"resource.updated",
-		"resource.deleted",
+func routeForMessage(topic string, schemaVersion int) string {
+	if action, ok := messageRoutes[routeKey{topic: topic, schemaVersion: schemaVersion}]; ok {
+		return action
 	}
-
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:        []string{kafkaBrokers},
-		GroupID:        consumerGroup,
-		GroupTopics:    topics,
-		MinBytes:       10e3, // 10KB
-		MaxBytes:       10e6, // 10MB
-		CommitInterval: time.Second,
-		StartOffset:    kafka.LastOffset,
-	})
-	defer reader.Close()
-
-	for {
-		select {
-		case <-ctx.Done():
-			logger.Info("Stopping Kafka consumer")
-			return
-		default:
-			msg, err := reader.FetchMessage(ctx)
-			if err != nil {
-				if err == context.Canceled {
-					return
-				}
-				logger.Error("Failed to fetch message", zap.Error(err))
-				continue
-			}
-
-			if err := processKafkaMessage(ctx, msg); err != nil {
-				logger.Error("Failed to process message", 
-					zap.String("topic", msg.Topic),
-					zap.String("key", string(msg.Key)),
-					zap.Error(err))
-			} else {
-				if err := reader.CommitMessages(ctx, msg); err != nil {
-					logger.Error("Failed to commit message", zap.Error(err))
-				}
-			}
-		}
+	if action, ok := messageRoutes[routeKey{topic: topic, schemaVersion: 0}]; ok {
+		return action
 	}
+	return "unknown"
 }
 
-func processKafkaMessage(ctx context.Context, msg kafka.Message) error {
-	_, span := tracer.Start(ctx, "processKafkaMessage")
+// processKafkaMessage applies the business logic for a single message.
+// headers carries the raw Kafka headers so the producer's trace context can
+// be extracted and linked to the CONSUMER span started here.
+func processKafkaMessage(ctx context.Context, topic string, partition int32, offset int64, key, value []byte, headers []*sarama.RecordHeader) error {
+	ctx, span := startConsumerSpan(ctx, headers, "processKafkaMessage")
 	defer span.End()
 
 	logger.Info("Processing Kafka message",
-		zap.String("topic", msg.Topic),
-		zap.String("key", string(msg.Key)),
-		zap.Int64("offset", msg.Offset),
-		zap.Int("partition", msg.Partition))
-
-	// Parse the message
-	var payload map[string]interface{}
-	if err := json.Unmarshal(msg.Value, &payload); err != nil {
-		return fmt.Errorf("failed to unmarshal message: %w", err)
+		zap.String("topic", topic),
+		zap.String("key", string(key)),
+		zap.Int64("offset", offset),
+		zap.Int32("partition", partition))
+
+	// Decode the message through the codec configured for its topic (JSON by
+	// default, or Avro/Protobuf framed with the Confluent wire-format header).
+	decoded, err := decodeKafkaValue(topic, value)
+	if err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
 	}
 
 	// Extract resource ID
-	resourceID, ok := payload["id"].(string)
+	resourceID, ok := decoded.Payload["id"].(string)
 	if !ok {
 		return fmt.Errorf("missing or invalid resource ID in payload")
 	}
 
-	// Determine action based on topic
-	var action string
-	switch msg.Topic {
-	case "resource.created":
-		action = "process_new_resource"
-	case "resource.updated":
-		action = "reprocess_resource"
-	case "resource.deleted":
-		action = "cleanup_resource"
-	default:
-		action = "unknown"
-	}
+	// Route on (topic, schema version) so a topic can evolve its schema
+	// without silently falling back to "unknown" for every consumer.
+	action := routeForMessage(topic, decoded.SchemaVersion)
+
+	span.SetAttributes(
+		attribute.String("kafka.codec", string(decoded.Codec)),
+		attribute.Int("kafka.schema_version", decoded.SchemaVersion),
+	)
 
 	// Create a task for processing
 	taskID := fmt.Sprintf("auto-%s", resourceID)
@@ -117,7 +88,7 @@ func processKafkaMessage(ctx context.Context, msg kafka.Message) error {
 			  	status = 'processing',
 			  	updated_at = EXCLUDED.updated_at`
 	
-	result := fmt.Sprintf("Processing %s event for resource %s", msg.Topic, resourceID)
+	result := fmt.Sprintf("Processing %s event for resource %s", topic, resourceID)
 	_, err = tx.ExecContext(ctx, query, taskID, resourceID, action, "processing", result, now, now)
 	if err != nil {
 		return fmt.Errorf("failed to insert task: %w", err)
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/go-kafkify/grpc-service/eventbus"
+)
+
+const (
+	maxRetryAttempts = 3
+	retryBaseBackoff = 5 * time.Second
+	headerAttempt    = "delivery_attempt"
+	headerNotBefore  = "not_before"
+	headerOrigTopic  = "original_topic"
+	headerOrigPart   = "original_partition"
+	headerOrigOffset = "original_offset"
+)
+
+// isTransientError classifies a processing failure as retryable (DB hiccups,
+// timeouts, connection resets) versus permanent (the message itself is bad
+// and will never succeed). Unrecognized errors default to transient so we
+// retry rather than silently drop a message we don't understand.
+func isTransientError(err error) bool {
+	msg := err.Error()
+	permanentMarkers := []string{
+		"failed to unmarshal",
+		"failed to decode",
+		"missing or invalid",
+		"unknown codec",
+	}
+	for _, marker := range permanentMarkers {
+		if strings.Contains(msg, marker) {
+			return false
+		}
+	}
+	return true
+}
+
+func deliveryAttempt(headers []*sarama.RecordHeader) int {
+	for _, h := range headers {
+		if string(h.Key) == headerAttempt {
+			n, err := strconv.Atoi(string(h.Value))
+			if err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// handleFailedMessage is called after processKafkaMessage returns an error.
+// Transient failures within the retry budget are republished to a
+// backed-off retry topic; everything else (permanent failures, or transient
+// failures that exhausted retries) is quarantined to the dead-letter topic
+// and table.
+func handleFailedMessage(ctx context.Context, msg *sarama.ConsumerMessage, consumerGroup string, procErr error) error {
+	attempt := deliveryAttempt(msg.Headers)
+	if isTransientError(procErr) && attempt < maxRetryAttempts {
+		return publishToRetryTopic(ctx, msg, attempt+1, procErr)
+	}
+	return publishToDLQ(ctx, msg, consumerGroup, procErr)
+}
+
+// publishToRetryTopic republishes msg to "<topic>.retry.<attempt>" with a
+// not-before header honored by the retry consumer, so the message sleeps out
+// its backoff window before being replayed against the business logic again.
+func publishToRetryTopic(ctx context.Context, msg *sarama.ConsumerMessage, attempt int, procErr error) error {
+	backoff := retryBaseBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+	}
+	notBefore := time.Now().Add(backoff)
+	retryTopic := fmt.Sprintf("%s.retry.%d", msg.Topic, attempt)
+
+	headers := []eventbus.Header{
+		{Key: headerAttempt, Value: []byte(strconv.Itoa(attempt))},
+		{Key: headerNotBefore, Value: []byte(notBefore.Format(time.RFC3339))},
+		{Key: headerOrigTopic, Value: []byte(msg.Topic)},
+		{Key: headerOrigPart, Value: []byte(strconv.FormatInt(int64(msg.Partition), 10))},
+		{Key: headerOrigOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		{Key: "error", Value: []byte(procErr.Error())},
+	}
+
+	if err := produceSingle(ctx, retryTopic, msg.Key, msg.Value, headers); err != nil {
+		return fmt.Errorf("failed to publish to retry topic %s: %w", retryTopic, err)
+	}
+
+	logger.Warn("Message sent to retry tier",
+		zap.String("original_topic", msg.Topic),
+		zap.String("retry_topic", retryTopic),
+		zap.Int("attempt", attempt),
+		zap.Time("not_before", notBefore),
+		zap.Error(procErr))
+	return nil
+}
+
+// publishToDLQ quarantines a poison message: it is produced to
+// "<topic>.dlq" for operators tailing dead-letter traffic, and its metadata
+// is persisted to dead_letter_events so it can be inspected/replayed later
+// via the ReplayDeadLetter RPC.
+func publishToDLQ(ctx context.Context, msg *sarama.ConsumerMessage, consumerGroup string, procErr error) error {
+	dlqTopic := msg.Topic + ".dlq"
+	eventID := uuid.New().String()
+
+	headers := []eventbus.Header{
+		{Key: "event_id", Value: []byte(eventID)},
+		{Key: headerOrigTopic, Value: []byte(msg.Topic)},
+		{Key: headerOrigPart, Value: []byte(strconv.FormatInt(int64(msg.Partition), 10))},
+		{Key: headerOrigOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		{Key: "error", Value: []byte(procErr.Error())},
+		{Key: "consumer_group", Value: []byte(consumerGroup)},
+	}
+
+	if err := produceSingle(ctx, dlqTopic, msg.Key, msg.Value, headers); err != nil {
+		return fmt.Errorf("failed to publish to dlq topic %s: %w", dlqTopic, err)
+	}
+
+	query := `INSERT INTO dead_letter_events
+			  (id, original_topic, original_partition, original_offset, event_key, payload, error_message, consumer_group, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err := db.ExecContext(ctx, query, eventID, msg.Topic, msg.Partition, msg.Offset,
+		string(msg.Key), string(msg.Value), procErr.Error(), consumerGroup, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to persist dead letter event: %w", err)
+	}
+
+	logger.Error("Message quarantined to dead-letter queue",
+		zap.String("event_id", eventID),
+		zap.String("original_topic", msg.Topic),
+		zap.String("dlq_topic", dlqTopic),
+		zap.Error(procErr))
+	return nil
+}
+
+var (
+	eventBusProducer     eventbus.Producer
+	eventBusProducerOnce sync.Once
+	eventBusProducerErr  error
+)
+
+// getEventBusProducer lazily builds the eventbus.Producer used for every
+// single-record publish path (retry tier, DLQ, dead-letter replay). It is
+// intentionally separate from the outbox relay's transactional *kgo.Client:
+// that client's transactional ID makes every Produce call part of a
+// multi-message Kafka transaction, which a lone DLQ/retry publish has no use
+// for.
+func getEventBusProducer() (eventbus.Producer, error) {
+	eventBusProducerOnce.Do(func() {
+		backend := eventbus.Backend(getEnv("EVENT_BUS_BACKEND", string(eventbus.BackendFranzGo)))
+		eventBusProducer, eventBusProducerErr = eventbus.NewProducer(backend, eventbus.ProducerConfig{
+			Brokers: getEnv("KAFKA_BROKERS", "localhost:9092"),
+		})
+	})
+	return eventBusProducer, eventBusProducerErr
+}
+
+// produceSingle publishes one message through the configured eventbus
+// backend, used by the retry tier, DLQ, and dead-letter replay.
+func produceSingle(ctx context.Context, topic string, key, value []byte, headers []eventbus.Header) error {
+	producer, err := getEventBusProducer()
+	if err != nil {
+		return fmt.Errorf("failed to initialize event bus producer: %w", err)
+	}
+	return producer.Publish(ctx, topic, key, value, headers)
+}
+
+// replayDeadLetterEvent looks up a dead_letter_events row by ID and
+// republishes its original payload to its original topic, then stamps
+// replayed_at so it doesn't show up as still-quarantined.
+func replayDeadLetterEvent(ctx context.Context, eventID string) (string, error) {
+	var topic, key, payload string
+	query := `SELECT original_topic, event_key, payload FROM dead_letter_events WHERE id = $1`
+	if err := db.QueryRowContext(ctx, query, eventID).Scan(&topic, &key, &payload); err != nil {
+		return "", err
+	}
+
+	headers := []eventbus.Header{
+		{Key: "event_id", Value: []byte(eventID)},
+		{Key: "replayed", Value: []byte("true")},
+	}
+	if err := produceSingle(ctx, topic, []byte(key), []byte(payload), headers); err != nil {
+		return "", fmt.Errorf("failed to republish dead letter event: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `UPDATE dead_letter_events SET replayed_at = $1 WHERE id = $2`, time.Now(), eventID); err != nil {
+		return "", fmt.Errorf("failed to mark dead letter event replayed: %w", err)
+	}
+
+	return topic, nil
+}
+
+// retryTopicConsumer tails every "<topic>.retry.<n>" topic and sleeps each
+// message out until its not_before header elapses before replaying it
+// through processKafkaMessage, escalating to the next retry tier or the DLQ
+// on repeated failure.
+func retryTopicConsumer(ctx context.Context, consumerGroupID string, baseTopics []string) {
+	var retryTopics []string
+	for _, topic := range baseTopics {
+		for attempt := 1; attempt <= maxRetryAttempts; attempt++ {
+			retryTopics = append(retryTopics, fmt.Sprintf("%s.retry.%d", topic, attempt))
+		}
+	}
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_8_0_0
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	consumer, err := sarama.NewConsumer([]string{getEnv("KAFKA_BROKERS", "localhost:9092")}, config)
+	if err != nil {
+		logger.Error("Failed to start retry topic consumer", zap.Error(err))
+		return
+	}
+	defer consumer.Close()
+
+	for _, topic := range retryTopics {
+		pc, err := consumer.ConsumePartition(topic, 0, sarama.OffsetOldest)
+		if err != nil {
+			logger.Warn("Retry topic not available yet, skipping", zap.String("topic", topic), zap.Error(err))
+			continue
+		}
+		go consumeRetryPartition(ctx, pc, consumerGroupID)
+	}
+
+	<-ctx.Done()
+}
+
+func consumeRetryPartition(ctx context.Context, pc sarama.PartitionConsumer, consumerGroupID string) {
+	defer pc.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-pc.Messages():
+			if msg == nil {
+				return
+			}
+			waitForNotBefore(ctx, msg.Headers)
+
+			origTopic := headerValue(msg.Headers, headerOrigTopic)
+			if origTopic == "" {
+				origTopic = strings.TrimSuffix(msg.Topic, retrySuffix(msg.Topic))
+			}
+
+			err := processKafkaMessage(ctx, origTopic, msg.Partition, msg.Offset, msg.Key, msg.Value, msg.Headers)
+			if err == nil {
+				continue
+			}
+
+			attempt := deliveryAttempt(msg.Headers)
+			replay := &sarama.ConsumerMessage{
+				Topic:     origTopic,
+				Partition: msg.Partition,
+				Offset:    msg.Offset,
+				Key:       msg.Key,
+				Value:     msg.Value,
+				Headers:   msg.Headers,
+			}
+			if handleErr := handleFailedMessage(ctx, replay, consumerGroupID, err); handleErr != nil {
+				logger.Error("Failed to escalate retry message",
+					zap.String("topic", msg.Topic), zap.Int("attempt", attempt), zap.Error(handleErr))
+			}
+		}
+	}
+}
+
+func waitForNotBefore(ctx context.Context, headers []*sarama.RecordHeader) {
+	raw := headerValue(headers, headerNotBefore)
+	if raw == "" {
+		return
+	}
+	notBefore, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return
+	}
+	wait := time.Until(notBefore)
+	if wait <= 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(wait):
+	}
+}
+
+func headerValue(headers []*sarama.RecordHeader, key string) string {
+	for _, h := range headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// retrySuffix extracts the ".retry.N" suffix from a retry topic name, used
+// as a fallback when no original_topic header is present (should not
+// normally happen).
+func retrySuffix(topic string) string {
+	idx := strings.Index(topic, ".retry.")
+	if idx == -1 {
+		return ""
+	}
+	return topic[idx:]
+}
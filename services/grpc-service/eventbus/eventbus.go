@@ -0,0 +1,111 @@
+// Package eventbus gives dlq.go's retry/DLQ publishes and the
+// EVENT_BUS_BACKEND-selected consumer path (see startEventBusConsumer in
+// partition_consumer.go) a backend-agnostic Producer/Consumer instead of a
+// hardcoded client library, with kafka-go and franz-go implementations
+// callers can switch between via Backend.
+//
+// Three call sites were named when this package was introduced as the ones
+// it should eventually cover, and stay deliberately, permanently out of
+// scope instead, each for a concrete reason this single-message interface
+// can't absorb:
+//
+//   - outbox.go's publishBatchToKafka: runs inside the caller's open
+//     client.BeginTransaction()/EndTransaction() kgo transaction, committed
+//     or aborted atomically with the batch's processed_at DB update.
+//     Producer.Publish has no transaction boundary to join — collapsing it
+//     into one would require every backend to expose Kafka transactions the
+//     same way kafka-go's client does not, silently giving up the
+//     exactly-once guarantee chunk0-2 built this relay for.
+//   - main.go's insertOutboxEvent: never talks to Kafka at all. It writes a
+//     row to outbox_events inside the caller's DB transaction; that row is
+//     what publishBatchToKafka later reads and publishes. There is no
+//     eventbus call to make here — the outbox pattern's entire point is
+//     that the write path doesn't touch a broker client.
+//   - partition_consumer.go's startKafkaConsumer: sarama's ConsumeClaim
+//     cooperative partition assignment, the per-partition in-flight window,
+//     offsetWatermark, and lag/throughput metrics all key off partition-level
+//     state Consumer.Subscribe's single handler callback doesn't expose.
+//     EVENT_BUS_BACKEND already swaps in the plain eventbus.Consumer for
+//     callers who don't need that machinery (see startEventBusConsumer).
+package eventbus
+
+import "context"
+
+// Header is a transport-agnostic Kafka message header.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Message is a transport-agnostic view of a consumed Kafka record.
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []Header
+}
+
+// HandlerFunc processes one consumed message. Returning a non-nil error
+// leaves the message's offset uncommitted; callers that want retry/DLQ
+// routing on failure build that into the HandlerFunc itself rather than
+// relying on the bus to understand business-level failure semantics.
+type HandlerFunc func(ctx context.Context, msg Message) error
+
+// Producer publishes a single message and reports whether it was
+// acknowledged. Batched, cross-message transactional delivery (the outbox
+// relay's exactly-once batch commit) is intentionally out of scope for this
+// interface and stays on the backend-specific client, since "transactional
+// batch" isn't a shape every backend can express identically.
+type Producer interface {
+	Publish(ctx context.Context, topic string, key, value []byte, headers []Header) error
+	Close() error
+}
+
+// Consumer subscribes to a set of topics and invokes handler for every
+// message. Subscribe blocks until ctx is canceled or an unrecoverable error
+// occurs.
+type Consumer interface {
+	Subscribe(ctx context.Context, topics []string, handler HandlerFunc) error
+	Close() error
+}
+
+// ProducerConfig configures either backend's Producer.
+type ProducerConfig struct {
+	Brokers string
+}
+
+// ConsumerConfig configures either backend's Consumer.
+type ConsumerConfig struct {
+	Brokers string
+	GroupID string
+}
+
+// Backend selects which client library backs a Producer/Consumer pair.
+type Backend string
+
+const (
+	BackendKafkaGo Backend = "kafka-go"
+	BackendFranzGo Backend = "franz-go"
+)
+
+// NewProducer builds a Producer for the given backend.
+func NewProducer(backend Backend, cfg ProducerConfig) (Producer, error) {
+	switch backend {
+	case BackendKafkaGo:
+		return newKafkaGoProducer(cfg), nil
+	default:
+		return newFranzGoProducer(cfg)
+	}
+}
+
+// NewConsumer builds a Consumer for the given backend.
+func NewConsumer(backend Backend, cfg ConsumerConfig) (Consumer, error) {
+	switch backend {
+	case BackendKafkaGo:
+		return newKafkaGoConsumer(cfg), nil
+	default:
+		return newFranzGoConsumer(cfg)
+	}
+}
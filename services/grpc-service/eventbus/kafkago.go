@@ -0,0 +1,147 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaGoProducer is the original, pre-eventbus transport: one kafka.Writer
+// per topic, created lazily and cached for reuse across Publish calls.
+type kafkaGoProducer struct {
+	brokers string
+
+	mu      sync.Mutex
+	writers map[string]*kafka.Writer
+}
+
+func newKafkaGoProducer(cfg ProducerConfig) *kafkaGoProducer {
+	return &kafkaGoProducer{
+		brokers: cfg.Brokers,
+		writers: make(map[string]*kafka.Writer),
+	}
+}
+
+func (p *kafkaGoProducer) writerFor(topic string) *kafka.Writer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if w, ok := p.writers[topic]; ok {
+		return w
+	}
+	w := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:      []string{p.brokers},
+		Topic:        topic,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+	})
+	p.writers[topic] = w
+	return w
+}
+
+func (p *kafkaGoProducer) Publish(ctx context.Context, topic string, key, value []byte, headers []Header) error {
+	msg := kafka.Message{
+		Key:   key,
+		Value: value,
+	}
+	for _, h := range headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: h.Key, Value: h.Value})
+	}
+
+	if err := p.writerFor(topic).WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("kafka-go: failed to write message to %s: %w", topic, err)
+	}
+	return nil
+}
+
+func (p *kafkaGoProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var firstErr error
+	for _, w := range p.writers {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// kafkaGoConsumer is the simple, single-threaded consumer-group reader the
+// service originally shipped with. It trades the sarama path's per-partition
+// worker pool for a much smaller surface area, which makes it
+// a reasonable choice for low-throughput topics or local development.
+type kafkaGoConsumer struct {
+	brokers string
+	groupID string
+
+	mu     sync.Mutex
+	reader *kafka.Reader
+}
+
+func newKafkaGoConsumer(cfg ConsumerConfig) *kafkaGoConsumer {
+	return &kafkaGoConsumer{brokers: cfg.Brokers, groupID: cfg.GroupID}
+}
+
+func (c *kafkaGoConsumer) Subscribe(ctx context.Context, topics []string, handler HandlerFunc) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        []string{c.brokers},
+		GroupID:        c.groupID,
+		GroupTopics:    topics,
+		MinBytes:       10e3,
+		MaxBytes:       10e6,
+		CommitInterval: time.Second,
+		StartOffset:    kafka.LastOffset,
+	})
+	c.mu.Lock()
+	c.reader = reader
+	c.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if err == context.Canceled {
+				return nil
+			}
+			return fmt.Errorf("kafka-go: failed to fetch message: %w", err)
+		}
+
+		headers := make([]Header, len(msg.Headers))
+		for i, h := range msg.Headers {
+			headers[i] = Header{Key: h.Key, Value: h.Value}
+		}
+
+		handlerErr := handler(ctx, Message{
+			Topic:     msg.Topic,
+			Partition: int32(msg.Partition),
+			Offset:    msg.Offset,
+			Key:       msg.Key,
+			Value:     msg.Value,
+			Headers:   headers,
+		})
+		if handlerErr != nil {
+			continue
+		}
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			return fmt.Errorf("kafka-go: failed to commit message: %w", err)
+		}
+	}
+}
+
+func (c *kafkaGoConsumer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reader == nil {
+		return nil
+	}
+	return c.reader.Close()
+}
@@ -46,11 +46,12 @@ type Task struct {
 }
 
 type OutboxEvent struct {
-	ID          string    `json:"id"`
-	AggregateID string    `json:"aggregate_id"`
-	EventType   string    `json:"event_type"`
-	Payload     string    `json:"payload"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID            string    `json:"id"`
+	AggregateID   string    `json:"aggregate_id"`
+	EventType     string    `json:"event_type"`
+	Payload       string    `json:"payload"`
+	SchemaSubject string    `json:"schema_subject"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type taskServer struct {
@@ -88,6 +89,11 @@ func main() {
 
 	go startKafkaConsumer(ctx)
 	go startOutboxProcessor(ctx)
+	go retryTopicConsumer(ctx, getEnv("KAFKA_CONSUMER_GROUP_GRPC", "grpc-service-group"), []string{
+		"resource.created",
+		"resource.updated",
+		"resource.deleted",
+	})
 
 	// Start metrics server
 	go startMetricsServer()
@@ -213,6 +219,42 @@ func (s *taskServer) GetTaskStatus(ctx context.Context, req *taskv1.GetTaskStatu
 	}, nil
 }
 
+// ReplayDeadLetter republishes a quarantined message to its original topic so
+// operators can recover from a poison message once its root cause (e.g. a bad
+// deploy or a schema mismatch) has been fixed. The message is identified by
+// its dead-letter event ID, taken from event_id or, when that is empty, from
+// task_id; both are matched against dead_letter_events.id, not against the
+// original Kafka message key.
+func (s *taskServer) ReplayDeadLetter(ctx context.Context, req *taskv1.ReplayDeadLetterRequest) (*taskv1.ReplayDeadLetterResponse, error) {
+	_, span := tracer.Start(ctx, "ReplayDeadLetter")
+	defer span.End()
+
+	id := req.EventId
+	if id == "" {
+		id = req.TaskId
+	}
+	if id == "" {
+		return nil, fmt.Errorf("either event_id or task_id must be provided")
+	}
+
+	replayedTopic, err := replayDeadLetterEvent(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("dead letter event not found: %s", id)
+	}
+	if err != nil {
+		logger.Error("Failed to replay dead letter event", zap.String("event_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("Replayed dead letter event", zap.String("event_id", id), zap.String("topic", replayedTopic))
+
+	return &taskv1.ReplayDeadLetterResponse{
+		EventId: id,
+		Topic:   replayedTopic,
+		Status:  "replayed",
+	}, nil
+}
+
 func (s *taskServer) ListTasks(ctx context.Context, req *taskv1.ListTasksRequest) (*taskv1.ListTasksResponse, error) {
 	_, span := tracer.Start(ctx, "ListTasks")
 	defer span.End()
@@ -252,19 +294,33 @@ func (s *taskServer) ListTasks(ctx context.Context, req *taskv1.ListTasksRequest
 	}, nil
 }
 
+// insertOutboxEvent stages an event row inside the caller's DB transaction
+// for outbox.go's publishBatchToKafka to publish later; it never talks to
+// Kafka itself, so there is no eventbus.Producer call to make here (see the
+// eventbus package doc for the three call sites that were considered and
+// why each stays outside that abstraction).
 func insertOutboxEvent(ctx context.Context, tx *sql.Tx, aggregateID, eventType string, payload interface{}) error {
+	_, span := tracer.Start(ctx, "insertOutboxEvent")
+	defer span.End()
+
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
 	eventID := uuid.New().String()
-	query := `INSERT INTO outbox_events (id, aggregate_id, event_type, payload, created_at)
-			  VALUES ($1, $2, $3, $4, $5)`
-	_, err = tx.ExecContext(ctx, query, eventID, aggregateID, eventType, string(payloadJSON), time.Now())
+	schemaSubject := eventType + "-value"
+	query := `INSERT INTO outbox_events (id, aggregate_id, event_type, payload, schema_subject, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.ExecContext(ctx, query, eventID, aggregateID, eventType, string(payloadJSON), schemaSubject, time.Now())
 	return err
 }
 
+// initDB opens the connection pool this service reads and writes task and
+// outbox state through. It does not apply migrations/: those files are
+// intended to be run out-of-band by a migration tool (e.g. golang-migrate)
+// as a deploy step ahead of rolling out a new binary, the same as
+// rest-service's migrations/. Nothing in this repo wires a runner in-process.
 func initDB() (*sql.DB, error) {
 	dbHost := getEnv("GRPC_DB_HOST", "localhost")
 	dbPort := getEnv("GRPC_DB_PORT", "5432")
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelPropagator implements the W3C traceparent/tracestate format used to
+// carry span context across the Kafka hop in message headers.
+var otelPropagator = propagation.TraceContext{}
+
+// injectTraceHeaders appends traceparent/tracestate headers derived from the
+// active span in ctx onto a producer record, so a consumer on the other side
+// of the topic can pick the trace back up instead of starting a new one.
+func injectTraceHeaders(ctx context.Context, headers []kgo.RecordHeader) []kgo.RecordHeader {
+	carrier := propagation.MapCarrier{}
+	otelPropagator.Inject(ctx, carrier)
+	for key, value := range carrier {
+		headers = append(headers, kgo.RecordHeader{Key: key, Value: []byte(value)})
+	}
+	return headers
+}
+
+// extractTraceContext rebuilds a context carrying the producer's span
+// context from a consumed message's headers.
+func extractTraceContext(ctx context.Context, headers []*sarama.RecordHeader) context.Context {
+	carrier := propagation.MapCarrier{}
+	for _, h := range headers {
+		carrier[string(h.Key)] = string(h.Value)
+	}
+	return otelPropagator.Extract(ctx, carrier)
+}
+
+// startConsumerSpan starts a CONSUMER span for a just-fetched message, linked
+// to (rather than parented by) the producer's span, since the producer span
+// already ended by the time the message was delivered.
+func startConsumerSpan(ctx context.Context, headers []*sarama.RecordHeader, spanName string) (context.Context, trace.Span) {
+	producerCtx := extractTraceContext(ctx, headers)
+	link := trace.LinkFromContext(producerCtx)
+	return tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindConsumer), trace.WithLinks(link))
+}
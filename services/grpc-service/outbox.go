@@ -2,17 +2,36 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/segmentio/kafka-go"
+	"github.com/twmb/franz-go/pkg/kgo"
 	"go.uber.org/zap"
 )
 
+const outboxBatchSize = 100
+
+var (
+	producerClientMu sync.Mutex
+	producerClient   *kgo.Client
+)
+
 func startOutboxProcessor(ctx context.Context) {
-	logger.Info("Starting outbox processor")
+	mode := getEnv("OUTBOX_MODE", "poll")
+	logger.Info("Starting outbox processor", zap.String("mode", mode))
 
-	kafkaBrokers := getEnv("KAFKA_BROKERS", "localhost:9092")
+	defer func() {
+		if producerClient != nil {
+			producerClient.Close()
+		}
+	}()
+
+	if mode == "cdc" {
+		cdcOutboxProcessor(ctx)
+		return
+	}
 
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
@@ -23,38 +42,83 @@ func startOutboxProcessor(ctx context.Context) {
 			logger.Info("Stopping outbox processor")
 			return
 		case <-ticker.C:
-			if err := processOutboxEvents(ctx, kafkaBrokers); err != nil {
+			if err := processOutboxEvents(ctx); err != nil {
 				logger.Error("Failed to process outbox events", zap.Error(err))
 			}
 		}
 	}
 }
 
-func processOutboxEvents(ctx context.Context, kafkaBrokers string) error {
-	_, span := tracer.Start(ctx, "processOutboxEvents")
+// getProducerClient lazily builds the transactional franz-go client used by
+// the outbox relay. One transactional ID per process instance is required by
+// the Kafka transaction coordinator, so the client is created once and reused
+// across batches. Unlike a sync.Once, a failed build is not cached: brokers
+// can be briefly unreachable at process start, and pinning that error for
+// the process lifetime would wedge the outbox relay until a restart, so the
+// next tick's caller gets a fresh attempt instead.
+func getProducerClient() (*kgo.Client, error) {
+	producerClientMu.Lock()
+	defer producerClientMu.Unlock()
+
+	if producerClient != nil {
+		return producerClient, nil
+	}
+
+	brokers := getEnv("KAFKA_BROKERS", "localhost:9092")
+	transactionalID := getEnv("KAFKA_TRANSACTIONAL_ID", "grpc-service-outbox")
+
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers),
+		kgo.TransactionalID(transactionalID),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		kgo.MaxProduceRequestsInflightPerBroker(1),
+		kgo.ProducerBatchCompression(kgo.SnappyCompression()),
+	)
+	if err != nil {
+		logger.Error("Failed to build kafka producer client; will retry on the next outbox tick", zap.Error(err))
+		return nil, err
+	}
+
+	producerClient = client
+	return producerClient, nil
+}
+
+// processOutboxEvents publishes up to outboxBatchSize pending events inside a
+// single Kafka transaction and only marks them processed once that
+// transaction has committed. If the Kafka commit fails the batch is aborted
+// and nothing is marked processed; if the follow-up DB commit fails the
+// events remain flagged unprocessed-but-published for reconciliation, since a
+// committed Kafka transaction cannot be retracted.
+func processOutboxEvents(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "processOutboxEvents")
 	defer span.End()
 
-	query := `SELECT id, aggregate_id, event_type, payload, created_at 
-			  FROM outbox_events 
-			  WHERE processed_at IS NULL 
-			  ORDER BY created_at ASC 
-			  LIMIT 100`
+	client, err := getProducerClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize kafka producer: %w", err)
+	}
+
+	query := `SELECT id, aggregate_id, event_type, payload, schema_subject, created_at
+			  FROM outbox_events
+			  WHERE processed_at IS NULL
+			  ORDER BY created_at ASC
+			  LIMIT $1`
 
-	rows, err := db.QueryContext(ctx, query)
+	rows, err := db.QueryContext(ctx, query, outboxBatchSize)
 	if err != nil {
 		return fmt.Errorf("failed to query outbox events: %w", err)
 	}
-	defer rows.Close()
 
 	events := []OutboxEvent{}
 	for rows.Next() {
 		var event OutboxEvent
-		if err := rows.Scan(&event.ID, &event.AggregateID, &event.EventType, &event.Payload, &event.CreatedAt); err != nil {
+		if err := rows.Scan(&event.ID, &event.AggregateID, &event.EventType, &event.Payload, &event.SchemaSubject, &event.CreatedAt); err != nil {
 			logger.Error("Failed to scan outbox event", zap.Error(err))
 			continue
 		}
 		events = append(events, event)
 	}
+	rows.Close()
 
 	if len(events) == 0 {
 		return nil
@@ -62,60 +126,111 @@ func processOutboxEvents(ctx context.Context, kafkaBrokers string) error {
 
 	logger.Info("Processing outbox events", zap.Int("count", len(events)))
 
-	for _, event := range events {
-		if err := publishToKafka(ctx, kafkaBrokers, event); err != nil {
-			logger.Error("Failed to publish event to Kafka",
-				zap.String("event_id", event.ID),
-				zap.Error(err))
-			continue
+	// Stage the processed_at update in an uncommitted DB transaction so it can
+	// be rolled back if the Kafka transaction fails to commit.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := client.BeginTransaction(); err != nil {
+		return fmt.Errorf("failed to begin kafka transaction: %w", err)
+	}
+
+	if err := publishBatchToKafka(ctx, client, events); err != nil {
+		if endErr := client.EndTransaction(ctx, kgo.TransactionEndAbort); endErr != nil {
+			logger.Error("Failed to abort kafka transaction", zap.Error(endErr))
 		}
+		return fmt.Errorf("failed to publish batch to kafka: %w", err)
+	}
 
-		if err := markEventProcessed(ctx, event.ID); err != nil {
-			logger.Error("Failed to mark event as processed",
-				zap.String("event_id", event.ID),
-				zap.Error(err))
+	for _, event := range events {
+		if err := markEventProcessed(ctx, tx, event.ID); err != nil {
+			if endErr := client.EndTransaction(ctx, kgo.TransactionEndAbort); endErr != nil {
+				logger.Error("Failed to abort kafka transaction", zap.Error(endErr))
+			}
+			return fmt.Errorf("failed to stage processed_at update for event %s: %w", event.ID, err)
 		}
 	}
 
+	if err := client.EndTransaction(ctx, kgo.TransactionEndCommit); err != nil {
+		return fmt.Errorf("failed to commit kafka transaction: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Kafka transaction committed but DB commit failed; events will be reprocessed on replay",
+			zap.Int("count", len(events)), zap.Error(err))
+		return fmt.Errorf("failed to commit db transaction: %w", err)
+	}
+
+	logger.Info("Outbox batch committed", zap.Int("count", len(events)))
 	return nil
 }
 
-func publishToKafka(ctx context.Context, brokers string, event OutboxEvent) error {
-	_, span := tracer.Start(ctx, "publishToKafka")
+// publishBatchToKafka produces every event in the batch within the caller's
+// open Kafka transaction and waits for all delivery results before
+// returning. It stays on *kgo.Client rather than eventbus.Producer: the
+// whole point of this function is that every Produce call here joins the
+// transaction processOutboxEvents opened, and Producer.Publish has no way to
+// express "join the caller's open transaction" (see the eventbus package
+// doc).
+func publishBatchToKafka(ctx context.Context, client *kgo.Client, events []OutboxEvent) error {
+	ctx, span := tracer.Start(ctx, "publishBatchToKafka")
 	defer span.End()
 
-	writer := kafka.NewWriter(kafka.WriterConfig{
-		Brokers:      []string{brokers},
-		Topic:        event.EventType,
-		Balancer:     &kafka.LeastBytes{},
-		BatchTimeout: 10 * time.Millisecond,
-	})
-	defer writer.Close()
-
-	message := kafka.Message{
-		Key:   []byte(event.AggregateID),
-		Value: []byte(event.Payload),
-		Headers: []kafka.Header{
+	var wg sync.WaitGroup
+	errs := make([]error, len(events))
+
+	for i, event := range events {
+		value, err := encodeForSubject(event.SchemaSubject, event.EventType, []byte(event.Payload))
+		if err != nil {
+			wg.Wait()
+			return fmt.Errorf("failed to encode event %s for kafka: %w", event.ID, err)
+		}
+
+		headers := injectTraceHeaders(ctx, []kgo.RecordHeader{
 			{Key: "event_id", Value: []byte(event.ID)},
 			{Key: "event_type", Value: []byte(event.EventType)},
-		},
+		})
+		record := &kgo.Record{
+			Topic:   event.EventType,
+			Key:     []byte(event.AggregateID),
+			Value:   value,
+			Headers: headers,
+		}
+
+		wg.Add(1)
+		idx := i
+		client.Produce(ctx, record, func(_ *kgo.Record, err error) {
+			defer wg.Done()
+			errs[idx] = err
+		})
 	}
 
-	err := writer.WriteMessages(ctx, message)
-	if err != nil {
-		return fmt.Errorf("failed to write message to Kafka: %w", err)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to publish event %s: %w", events[i].ID, err)
+		}
 	}
 
-	logger.Info("Event published to Kafka",
-		zap.String("event_id", event.ID),
-		zap.String("event_type", event.EventType),
-		zap.String("topic", event.EventType))
+	for _, event := range events {
+		logger.Info("Event published to Kafka",
+			zap.String("event_id", event.ID),
+			zap.String("event_type", event.EventType),
+			zap.String("topic", event.EventType))
+	}
 
 	return nil
 }
 
-func markEventProcessed(ctx context.Context, eventID string) error {
+func markEventProcessed(ctx context.Context, tx *sql.Tx, eventID string) error {
+	_, span := tracer.Start(ctx, "markEventProcessed")
+	defer span.End()
+
 	query := `UPDATE outbox_events SET processed_at = $1 WHERE id = $2`
-	_, err := db.ExecContext(ctx, query, time.Now(), eventID)
+	_, err := tx.ExecContext(ctx, query, time.Now(), eventID)
 	return err
 }
@@ -0,0 +1,44 @@
+package cloudevents
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BinaryHeaders returns e's attributes as the ce_-prefixed Kafka headers
+// defined by the CloudEvents Kafka protocol binding's binary content mode,
+// for callers that want to carry e.Data as the raw Kafka message value
+// instead of wrapping everything in a structured-mode envelope.
+func BinaryHeaders(e *Event) map[string][]byte {
+	headers := map[string][]byte{
+		"ce_specversion": []byte(e.SpecVersion),
+		"ce_id":          []byte(e.ID),
+		"ce_source":      []byte(e.Source),
+		"ce_type":        []byte(e.Type),
+		"ce_subject":     []byte(e.Subject),
+		"ce_time":        []byte(e.Time.Format(timeLayout)),
+		"content-type":   []byte(e.DataContentType),
+	}
+	if e.Traceparent != "" {
+		headers["traceparent"] = []byte(e.Traceparent)
+	}
+	return headers
+}
+
+const timeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// TraceparentFromContext formats the span context active on ctx as a W3C
+// traceparent header value ("00-traceid-spanid-flags"), or "" if ctx carries
+// no valid span context (e.g. tracing is disabled).
+func TraceparentFromContext(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	flags := "00"
+	if sc.IsSampled() {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-" + flags
+}
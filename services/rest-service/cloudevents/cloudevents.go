@@ -0,0 +1,65 @@
+// Package cloudevents wraps outbox payloads in a CloudEvents v1.0 envelope
+// (https://github.com/cloudevents/spec) so every event on the bus carries a
+// uniform set of attributes (id, source, type, subject, time, trace context)
+// regardless of which aggregate or handler produced it, instead of the bare
+// JSON blobs the outbox table used to hold.
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const specVersion = "1.0"
+
+// Event is a CloudEvents v1.0 envelope. Data holds the original payload,
+// marshaled as-is so Decode round-trips it without knowing its Go type.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Traceparent     string          `json:"traceparent,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// NewEvent builds an Event wrapping data. id is expected to be the outbox
+// event's own UUID, subject the aggregate ID, and traceparent the W3C
+// traceparent of the span active when the event was produced (empty if none).
+func NewEvent(id, source, eventType, subject string, data interface{}, traceparent string) (*Event, error) {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	return &Event{
+		SpecVersion:     specVersion,
+		ID:              id,
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Traceparent:     traceparent,
+		Data:            dataJSON,
+	}, nil
+}
+
+// Encode serializes e in CloudEvents structured content mode: the envelope
+// and its attributes as a single JSON document.
+func Encode(e *Event) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// Decode parses a structured-mode CloudEvents JSON document.
+func Decode(raw []byte) (*Event, error) {
+	var e Event
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, fmt.Errorf("failed to decode cloudevents envelope: %w", err)
+	}
+	return &e, nil
+}
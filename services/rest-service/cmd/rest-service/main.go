@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/go-kafkify/rest-service/outbox"
+	"github.com/go-kafkify/rest-service/resource"
+	"github.com/go-kafkify/rest-service/schemaregistry"
+	"github.com/go-kafkify/rest-service/server"
+	"github.com/go-kafkify/rest-service/storage"
+)
+
+func main() {
+	logger, err := server.NewLogger()
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tracer, shutdownTracer, err := server.NewTracer(ctx, "rest-service")
+	if err != nil {
+		logger.Fatal("Failed to initialize tracer", zap.Error(err))
+	}
+	defer shutdownTracer(context.Background())
+
+	db, err := server.NewDB(ctx, server.DBConfig{
+		Host:     getEnv("REST_DB_HOST", "localhost"),
+		Port:     getEnv("REST_DB_PORT", "5432"),
+		User:     getEnv("REST_DB_USER", "postgres"),
+		Password: getEnv("REST_DB_PASSWORD", "postgres"),
+		Name:     getEnv("REST_DB_NAME", "restdb"),
+		SSLMode:  getEnv("REST_DB_SSLMODE", "disable"),
+	}, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer db.Close()
+
+	schemaRegistry := schemaregistry.New(getEnv("SCHEMA_REGISTRY_URL", ""))
+
+	attachmentBucket := getEnv("ATTACHMENT_BUCKET", "resource-attachments")
+	objectStore, err := storage.NewMinIOStore(storage.Config{
+		Endpoint:  getEnv("ATTACHMENT_STORAGE_ENDPOINT", "localhost:9000"),
+		AccessKey: getEnv("ATTACHMENT_STORAGE_ACCESS_KEY", "minioadmin"),
+		SecretKey: getEnv("ATTACHMENT_STORAGE_SECRET_KEY", "minioadmin"),
+		Bucket:    attachmentBucket,
+		UseSSL:    getEnvBool("ATTACHMENT_STORAGE_USE_SSL", false),
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize attachment storage", zap.Error(err))
+	}
+
+	resourceHandler := resource.New(db, logger, tracer, schemaRegistry, objectStore, resource.AttachmentConfig{
+		Bucket:        attachmentBucket,
+		PresignTTL:    getEnvDuration("ATTACHMENT_PRESIGN_TTL", 15*time.Minute),
+		MaxUploadSize: getEnvInt64("ATTACHMENT_MAX_UPLOAD_SIZE", 100<<20),
+	})
+
+	publisher := outbox.NewKafkaPublisher(getEnv("KAFKA_BROKERS", "localhost:9092"), schemaRegistry)
+	defer publisher.Close()
+	outboxRelay := outbox.NewRelay(db, publisher, logger, outbox.Config{
+		BatchSize:   100,
+		Interval:    time.Second,
+		ContentMode: outbox.ContentMode(getEnv("CLOUDEVENTS_CONTENT_MODE", string(outbox.ContentModeStructured))),
+	})
+
+	app := &server.App{
+		DB:     db,
+		Logger: logger,
+		Tracer: tracer,
+		Outbox: outboxRelay,
+		Router: server.NewRouter(logger, resourceHandler),
+	}
+
+	go func() {
+		logger.Info("Starting outbox processor")
+		app.Outbox.Run(ctx)
+		logger.Info("Stopping outbox processor")
+	}()
+
+	port := getEnv("REST_SERVICE_PORT", "8080")
+	httpServer := &http.Server{
+		Addr:         ":" + port,
+		Handler:      app.Router,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	go func() {
+		logger.Info("Starting REST service", zap.String("port", port))
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Server failed", zap.Error(err))
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down server...")
+	cancel() // Stop outbox processor
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Fatal("Server forced to shutdown", zap.Error(err))
+	}
+
+	logger.Info("Server exited")
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
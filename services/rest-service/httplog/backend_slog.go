@@ -0,0 +1,43 @@
+//go:build slog_backend
+
+package httplog
+
+import (
+	"log/slog"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// slogLogger adapts a *slog.Logger to the Logger interface. Built with
+// -tags slog_backend in place of the zap-backed default in backend_zap.go.
+type slogLogger struct {
+	base *slog.Logger
+}
+
+// NewDefault builds the default Logger backend. The *zap.Logger parameter is
+// accepted for call-site parity with the zap backend (see backend_zap.go)
+// but is unused here.
+func NewDefault(_ *zap.Logger) Logger {
+	return &slogLogger{base: slog.New(slog.NewJSONHandler(os.Stdout, nil))}
+}
+
+func (l *slogLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.base.Debug(msg, keysAndValues...)
+}
+
+func (l *slogLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.base.Info(msg, keysAndValues...)
+}
+
+func (l *slogLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.base.Warn(msg, keysAndValues...)
+}
+
+func (l *slogLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.base.Error(msg, keysAndValues...)
+}
+
+func (l *slogLogger) With(keysAndValues ...interface{}) Logger {
+	return &slogLogger{base: l.base.With(keysAndValues...)}
+}
@@ -0,0 +1,37 @@
+//go:build !slog_backend
+
+package httplog
+
+import "go.uber.org/zap"
+
+// zapLogger adapts a *zap.SugaredLogger to the Logger interface. This is the
+// default backend; build with -tags slog_backend to swap in the log/slog
+// implementation in backend_slog.go instead.
+type zapLogger struct {
+	sugar *zap.SugaredLogger
+}
+
+// NewDefault builds the default Logger backend from a base *zap.Logger.
+func NewDefault(base *zap.Logger) Logger {
+	return &zapLogger{sugar: base.Sugar()}
+}
+
+func (l *zapLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.sugar.Debugw(msg, keysAndValues...)
+}
+
+func (l *zapLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.sugar.Infow(msg, keysAndValues...)
+}
+
+func (l *zapLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.sugar.Warnw(msg, keysAndValues...)
+}
+
+func (l *zapLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.sugar.Errorw(msg, keysAndValues...)
+}
+
+func (l *zapLogger) With(keysAndValues ...interface{}) Logger {
+	return &zapLogger{sugar: l.sugar.With(keysAndValues...)}
+}
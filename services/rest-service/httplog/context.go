@@ -0,0 +1,45 @@
+// Package httplog provides request-scoped structured logging for the REST
+// service. Middleware mints or propagates a correlation ID, stores a Logger
+// carrying it in the request context, and handlers retrieve it via
+// FromContext so every log line for a request's lifetime carries the same
+// request/trace IDs without explicitly threading them through call sites.
+package httplog
+
+import "context"
+
+// Logger is the structured logging surface handlers use. Both the zap
+// backend (backend_zap.go, the default) and the log/slog backend
+// (backend_slog.go, built with -tags slog_backend) implement it.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+	With(keysAndValues ...interface{}) Logger
+}
+
+type contextKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger, retrievable via
+// FromContext.
+func WithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the Logger stashed by WithLogger, or a no-op Logger if
+// none was installed (a call site outside the HTTP middleware chain, e.g. a
+// test calling a handler directly).
+func FromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return logger
+	}
+	return noopLogger{}
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Debug(string, ...interface{}) {}
+func (noopLogger) Info(string, ...interface{})  {}
+func (noopLogger) Warn(string, ...interface{})  {}
+func (noopLogger) Error(string, ...interface{}) {}
+func (noopLogger) With(...interface{}) Logger   { return noopLogger{} }
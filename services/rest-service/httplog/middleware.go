@@ -0,0 +1,81 @@
+package httplog
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	headerRequestID   = "X-Request-ID"
+	headerTraceparent = "traceparent"
+)
+
+// Middleware mints or propagates a correlation ID (X-Request-ID, falling
+// back to the trace ID in an inbound W3C traceparent header), attaches a
+// request-scoped Logger carrying it to the request context, wraps the
+// ResponseWriter to capture status code and response size, and emits an
+// access log line once the handler returns.
+func Middleware(base *zap.Logger) func(http.Handler) http.Handler {
+	logger := NewDefault(base)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(headerRequestID)
+			if requestID == "" {
+				requestID = traceIDFromTraceparent(r.Header.Get(headerTraceparent))
+			}
+			if requestID == "" {
+				requestID = uuid.New().String()
+			}
+			w.Header().Set(headerRequestID, requestID)
+
+			reqLogger := logger.With("request_id", requestID, "method", r.Method, "path", r.URL.Path)
+			ctx := WithLogger(r.Context(), reqLogger)
+
+			ww := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+			start := time.Now()
+			next.ServeHTTP(ww, r.WithContext(ctx))
+			duration := time.Since(start)
+
+			reqLogger.Info("request completed",
+				"status", ww.status,
+				"bytes", ww.bytes,
+				"duration_ms", duration.Milliseconds())
+		})
+	}
+}
+
+// traceIDFromTraceparent extracts the trace ID field from a W3C traceparent
+// header ("version-traceid-parentid-flags"), used as a correlation ID
+// fallback when the caller didn't send X-Request-ID.
+func traceIDFromTraceparent(traceparent string) string {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	return parts[1]
+}
+
+// responseWriter wraps http.ResponseWriter to capture the status code and
+// response size written by the handler, for the access log line.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
@@ -0,0 +1,25 @@
+package outbox
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	outboxBatchSizeMetric = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "rest_service_outbox_batch_size",
+		Help:    "Number of outbox events relayed per batch.",
+		Buckets: prometheus.LinearBuckets(0, 10, 11),
+	})
+
+	outboxLagSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "rest_service_outbox_lag_seconds",
+		Help: "Age of the oldest unprocessed outbox event in the most recently relayed batch.",
+	})
+
+	outboxPublishErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "rest_service_outbox_publish_errors_total",
+		Help: "Outbox events that failed to publish.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(outboxBatchSizeMetric, outboxLagSeconds, outboxPublishErrors)
+}
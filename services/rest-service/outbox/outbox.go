@@ -0,0 +1,191 @@
+// Package outbox implements the relay side of the transactional outbox
+// pattern: it polls outbox_events for rows not yet delivered, publishes each
+// one through a pluggable Publisher, and marks it processed only after a
+// successful ack. The CRUD handlers only ever need to write rows into
+// outbox_events inside their own transaction; everything downstream of that
+// lives here.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/go-kafkify/rest-service/cloudevents"
+)
+
+// Event is a single outbox_events row awaiting delivery.
+type Event struct {
+	ID            string
+	AggregateID   string
+	EventType     string
+	Payload       string
+	SchemaSubject string
+	CreatedAt     time.Time
+}
+
+// ContentMode selects how a published event carries its CloudEvents envelope
+// on the wire, per the CloudEvents Kafka protocol binding
+// (https://github.com/cloudevents/spec/blob/main/cloudevents/bindings/kafka-protocol-binding.md#32-binary-content-mode).
+type ContentMode string
+
+const (
+	// ContentModeStructured publishes event.Payload as-is: the full
+	// CloudEvents envelope as the Kafka message value. This is the default.
+	ContentModeStructured ContentMode = "structured"
+	// ContentModeBinary publishes the envelope's attributes as ce_-prefixed
+	// Kafka headers (see cloudevents.BinaryHeaders) and only its Data as the
+	// message value.
+	ContentModeBinary ContentMode = "binary"
+)
+
+// Config configures a Relay. Zero values fall back to sane defaults.
+type Config struct {
+	BatchSize   int
+	Interval    time.Duration
+	ContentMode ContentMode
+}
+
+// Relay polls outbox_events on Interval and publishes each due row through a
+// Publisher. Delivery is at-least-once: if the relay crashes between a
+// successful publish and its processed_at commit, the row is republished on
+// the next poll, so consumers must dedupe on the event ID header.
+type Relay struct {
+	db          *sql.DB
+	publisher   Publisher
+	logger      *zap.Logger
+	batchSize   int
+	interval    time.Duration
+	contentMode ContentMode
+}
+
+// NewRelay builds a Relay. The Publisher is owned by the caller, who is
+// responsible for closing it.
+func NewRelay(db *sql.DB, publisher Publisher, logger *zap.Logger, cfg Config) *Relay {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.ContentMode == "" {
+		cfg.ContentMode = ContentModeStructured
+	}
+	return &Relay{
+		db:          db,
+		publisher:   publisher,
+		logger:      logger,
+		batchSize:   cfg.BatchSize,
+		interval:    cfg.Interval,
+		contentMode: cfg.ContentMode,
+	}
+}
+
+// Run polls and relays batches until ctx is canceled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.relayBatch(ctx); err != nil {
+				r.logger.Error("Failed to relay outbox batch", zap.Error(err))
+			}
+		}
+	}
+}
+
+// relayBatch locks up to batchSize unprocessed rows with FOR UPDATE SKIP
+// LOCKED, so multiple rest-service replicas can run relays concurrently
+// without two of them picking up the same row, then publishes and marks each
+// one processed within that same transaction.
+func (r *Relay) relayBatch(ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, aggregate_id, event_type, payload, schema_subject, created_at
+			  FROM outbox_events
+			  WHERE processed_at IS NULL
+			  ORDER BY created_at ASC
+			  LIMIT $1
+			  FOR UPDATE SKIP LOCKED`
+	rows, err := tx.QueryContext(ctx, query, r.batchSize)
+	if err != nil {
+		return fmt.Errorf("failed to query outbox events: %w", err)
+	}
+
+	events := []Event{}
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.AggregateID, &e.EventType, &e.Payload, &e.SchemaSubject, &e.CreatedAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	outboxBatchSizeMetric.Observe(float64(len(events)))
+	outboxLagSeconds.Set(time.Since(events[0].CreatedAt).Seconds())
+
+	for _, event := range events {
+		if err := r.publishAndMark(ctx, tx, event); err != nil {
+			outboxPublishErrors.Inc()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit outbox batch: %w", err)
+	}
+
+	r.logger.Info("Outbox batch relayed", zap.Int("count", len(events)))
+	return nil
+}
+
+// publishAndMark publishes event with its aggregate ID as the partition key,
+// so all events for one entity land on the same partition and stay ordered,
+// and carries the event's own UUID as a header so consumers can dedupe
+// redeliveries idempotently.
+func (r *Relay) publishAndMark(ctx context.Context, tx *sql.Tx, event Event) error {
+	headers := map[string][]byte{
+		"event_id":       []byte(event.ID),
+		"event_type":     []byte(event.EventType),
+		"schema_subject": []byte(event.SchemaSubject),
+	}
+
+	value := []byte(event.Payload)
+	if r.contentMode == ContentModeBinary {
+		envelope, err := cloudevents.Decode([]byte(event.Payload))
+		if err != nil {
+			return fmt.Errorf("failed to decode envelope for binary content mode on event %s: %w", event.ID, err)
+		}
+		for name, val := range cloudevents.BinaryHeaders(envelope) {
+			headers[name] = val
+		}
+		value = envelope.Data
+	}
+
+	if err := r.publisher.Publish(ctx, event.EventType, []byte(event.AggregateID), value, headers); err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", event.ID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE outbox_events SET processed_at = $1 WHERE id = $2`, time.Now(), event.ID); err != nil {
+		return fmt.Errorf("failed to mark event %s processed: %w", event.ID, err)
+	}
+
+	return nil
+}
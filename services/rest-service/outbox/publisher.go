@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/go-kafkify/rest-service/schemaregistry"
+)
+
+// Publisher delivers one outbox event to its downstream topic. It is the
+// seam a future Debezium-style CDC source (reading outbox_events off
+// Postgres logical replication instead of polling) can sit behind without
+// the Relay or CRUD handlers needing to change.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, key, value []byte, headers map[string][]byte) error
+	Close() error
+}
+
+// KafkaPublisher publishes via segmentio/kafka-go, with one writer per topic
+// created lazily on first use.
+type KafkaPublisher struct {
+	brokers  string
+	registry *schemaregistry.Client
+
+	mu      sync.Mutex
+	writers map[string]*kafka.Writer
+}
+
+// NewKafkaPublisher builds a KafkaPublisher for the given broker list. registry
+// may be nil (or disabled, see schemaregistry.Client.Enabled), in which case
+// values are published as-is.
+func NewKafkaPublisher(brokers string, registry *schemaregistry.Client) *KafkaPublisher {
+	return &KafkaPublisher{
+		brokers:  brokers,
+		registry: registry,
+		writers:  make(map[string]*kafka.Writer),
+	}
+}
+
+func (p *KafkaPublisher) writerFor(topic string) *kafka.Writer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if w, ok := p.writers[topic]; ok {
+		return w
+	}
+	// Hash balances on the message key, which is set to the aggregate ID, so
+	// every event for one entity lands on the same partition and stays
+	// ordered.
+	w := &kafka.Writer{
+		Addr:     kafka.TCP(p.brokers),
+		Topic:    topic,
+		Balancer: &kafka.Hash{},
+	}
+	p.writers[topic] = w
+	return w
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, topic string, key, value []byte, headers map[string][]byte) error {
+	if p.registry != nil && p.registry.Enabled() {
+		framed, err := p.registry.EncodeForSubject(string(headers["schema_subject"]), value)
+		if err != nil {
+			return fmt.Errorf("schema registry: failed to frame value for %s: %w", topic, err)
+		}
+		value = framed
+	}
+
+	msg := kafka.Message{Key: key, Value: value}
+	for k, v := range headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: v})
+	}
+
+	if err := p.writerFor(topic).WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("kafka: failed to write message to %s: %w", topic, err)
+	}
+	return nil
+}
+
+func (p *KafkaPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var firstErr error
+	for _, w := range p.writers {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
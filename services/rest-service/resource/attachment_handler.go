@@ -0,0 +1,343 @@
+package resource
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/go-kafkify/rest-service/httplog"
+)
+
+// UploadAttachment streams a multipart file upload straight through to the
+// configured storage.ObjectStore: the request body is spooled to a temp file
+// (never fully buffered in memory) while its SHA-256 and size are computed,
+// then PUT to a presigned URL before the metadata row and outbox event are
+// written in one transaction.
+func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := h.tracer.Start(ctx, "uploadAttachment")
+	defer span.End()
+
+	resourceID := mux.Vars(r)["id"]
+	if exists, err := h.resourceExistsNoTx(ctx, resourceID); err != nil {
+		httplog.FromContext(ctx).Error("failed to check resource existence", "error", err)
+		http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+		return
+	} else if !exists {
+		http.Error(w, "Resource not found", http.StatusNotFound)
+		return
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected multipart/form-data body", http.StatusBadRequest)
+		return
+	}
+
+	part, err := nextFilePart(mr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := part.FileName()
+	if !validAttachmentName(name) {
+		http.Error(w, "File part must carry a filename with no path separators", http.StatusBadRequest)
+		return
+	}
+	contentType := part.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	spooled, err := spoolToTemp(part, h.attachments.MaxUploadSize)
+	if err != nil {
+		if errors.Is(err, errUploadTooLarge) {
+			http.Error(w, "Attachment exceeds maximum upload size", http.StatusRequestEntityTooLarge)
+			return
+		}
+		httplog.FromContext(ctx).Error("failed to spool attachment upload", "error", err)
+		http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+	defer spooled.cleanup()
+
+	objectKey := resourceID + "/" + name
+	putURL, err := h.store.PresignPut(ctx, objectKey, h.attachments.PresignTTL)
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to presign attachment upload", "error", err)
+		http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+
+	if err := putObject(ctx, putURL, contentType, spooled); err != nil {
+		httplog.FromContext(ctx).Error("failed to upload attachment to object store", "error", err)
+		http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+
+	att := Attachment{
+		ID:          uuid.New().String(),
+		ResourceID:  resourceID,
+		Name:        name,
+		Bucket:      h.attachments.Bucket,
+		ObjectKey:   objectKey,
+		SizeBytes:   spooled.size,
+		SHA256:      spooled.sha256,
+		ContentType: contentType,
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO resource_attachments (id, resource_id, name, bucket, object_key, size_bytes, sha256, content_type)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			  ON CONFLICT (resource_id, name) DO UPDATE
+			  SET bucket = EXCLUDED.bucket, object_key = EXCLUDED.object_key, size_bytes = EXCLUDED.size_bytes,
+				  sha256 = EXCLUDED.sha256, content_type = EXCLUDED.content_type, created_at = now()
+			  RETURNING created_at`
+	if err := tx.QueryRowContext(ctx, query, att.ID, att.ResourceID, att.Name, att.Bucket, att.ObjectKey,
+		att.SizeBytes, att.SHA256, att.ContentType).Scan(&att.CreatedAt); err != nil {
+		httplog.FromContext(ctx).Error("failed to insert attachment", "error", err)
+		http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.insertOutboxEvent(ctx, tx, resourceID, "resource.attachment.added", att, 0, 0); err != nil {
+		httplog.FromContext(ctx).Error("failed to insert outbox event", "error", err)
+		http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		httplog.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetAttributes(attribute.String("resource.id", resourceID), attribute.String("attachment.name", name))
+	httplog.FromContext(ctx).Info("attachment uploaded", "resource_id", resourceID, "name", name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(att)
+}
+
+// GetAttachment redirects to a presigned GET URL for the named attachment so
+// the object bytes flow directly from the store to the client.
+func (h *Handler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := h.tracer.Start(ctx, "getAttachment")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	resourceID, name := vars["id"], vars["name"]
+
+	var objectKey string
+	query := `SELECT object_key FROM resource_attachments WHERE resource_id = $1 AND name = $2`
+	err := h.db.QueryRowContext(ctx, query, resourceID, name).Scan(&objectKey)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		httplog.FromContext(ctx).Error("failed to look up attachment", "error", err)
+		http.Error(w, "Failed to fetch attachment", http.StatusInternalServerError)
+		return
+	}
+
+	getURL, err := h.store.PresignGet(ctx, objectKey, h.attachments.PresignTTL)
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to presign attachment download", "error", err)
+		http.Error(w, "Failed to fetch attachment", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetAttributes(attribute.String("resource.id", resourceID), attribute.String("attachment.name", name))
+	http.Redirect(w, r, getURL, http.StatusFound)
+}
+
+// DeleteAttachment removes the named attachment's object, metadata row, and
+// emits the corresponding outbox event in the same transaction as the row
+// delete.
+func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := h.tracer.Start(ctx, "deleteAttachment")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	resourceID, name := vars["id"], vars["name"]
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		http.Error(w, "Failed to delete attachment", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var att Attachment
+	query := `DELETE FROM resource_attachments WHERE resource_id = $1 AND name = $2 RETURNING id, bucket, object_key`
+	err = tx.QueryRowContext(ctx, query, resourceID, name).Scan(&att.ID, &att.Bucket, &att.ObjectKey)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		httplog.FromContext(ctx).Error("failed to delete attachment row", "error", err)
+		http.Error(w, "Failed to delete attachment", http.StatusInternalServerError)
+		return
+	}
+	att.ResourceID, att.Name = resourceID, name
+
+	if err := h.insertOutboxEvent(ctx, tx, resourceID, "resource.attachment.removed", att, 0, 0); err != nil {
+		httplog.FromContext(ctx).Error("failed to insert outbox event", "error", err)
+		http.Error(w, "Failed to delete attachment", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		httplog.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		http.Error(w, "Failed to delete attachment", http.StatusInternalServerError)
+		return
+	}
+
+	// The object is only removed once its row is durably gone, so a commit
+	// failure above can never leave a live row pointing at a deleted object.
+	// If Remove itself fails the object is merely orphaned, not referenced.
+	if err := h.store.Remove(ctx, att.ObjectKey); err != nil {
+		httplog.FromContext(ctx).Error("failed to remove attachment object", "error", err)
+	}
+
+	span.SetAttributes(attribute.String("resource.id", resourceID), attribute.String("attachment.name", name))
+	httplog.FromContext(ctx).Info("attachment deleted", "resource_id", resourceID, "name", name)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resourceExistsNoTx is resourceExists outside of a transaction, for the
+// attachment handlers that don't otherwise need one until they write.
+func (h *Handler) resourceExistsNoTx(ctx context.Context, id string) (bool, error) {
+	var exists bool
+	err := h.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM resources WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+// validAttachmentName rejects names that are empty, ".", ".." or contain a
+// path separator, so an attachment name is always safely usable both as a
+// single mux {name} path segment and as the tail of an object key scoped to
+// one resource (no traversal into another resource's prefix).
+func validAttachmentName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	return !strings.ContainsAny(name, `/\`)
+}
+
+// nextFilePart scans mr for the first part named "file".
+func nextFilePart(mr *multipart.Reader) (*multipart.Part, error) {
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, errors.New("missing file part")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read multipart body: %w", err)
+		}
+		if p.FormName() == "file" {
+			return p, nil
+		}
+	}
+}
+
+var errUploadTooLarge = errors.New("attachment exceeds maximum upload size")
+
+// spooledUpload is a hashed, size-counted copy of an upload spooled to disk
+// so neither the multipart read nor the object-store PUT ever holds the
+// whole body in memory at once.
+type spooledUpload struct {
+	file   *os.File
+	size   int64
+	sha256 string
+}
+
+func (s *spooledUpload) Read(p []byte) (int, error) { return s.file.Read(p) }
+
+func (s *spooledUpload) cleanup() {
+	name := s.file.Name()
+	s.file.Close()
+	os.Remove(name)
+}
+
+// spoolToTemp copies src to a temp file while hashing it, rejecting uploads
+// over maxSize (0 means unlimited), and rewinds the file so the caller can
+// read it back from the start.
+func spoolToTemp(src io.Reader, maxSize int64) (*spooledUpload, error) {
+	f, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	hasher := sha256.New()
+	limited := src
+	if maxSize > 0 {
+		limited = io.LimitReader(src, maxSize+1)
+	}
+
+	n, err := io.Copy(io.MultiWriter(f, hasher), limited)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to spool upload: %w", err)
+	}
+	if maxSize > 0 && n > maxSize {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, errUploadTooLarge
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to rewind spooled upload: %w", err)
+	}
+
+	return &spooledUpload{file: f, size: n, sha256: hex.EncodeToString(hasher.Sum(nil))}, nil
+}
+
+// putObject streams body to a presigned PUT URL.
+func putObject(ctx context.Context, presignedURL, contentType string, body *spooledUpload) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, presignedURL, body)
+	if err != nil {
+		return fmt.Errorf("failed to build put request: %w", err)
+	}
+	req.ContentLength = body.size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to put object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("object store returned status %d", resp.StatusCode)
+	}
+	return nil
+}
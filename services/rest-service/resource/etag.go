@@ -0,0 +1,29 @@
+package resource
+
+import (
+	"strconv"
+	"strings"
+)
+
+// etag formats a resource's version as a strong ETag header value.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// versionFromIfMatch parses an If-Match header value (a quoted ETag this
+// server previously issued, e.g. `"3"`, optionally weak-prefixed) into the
+// version it names. ok is false if ifMatch is empty or not a version we
+// could have produced.
+func versionFromIfMatch(ifMatch string) (version int, ok bool) {
+	ifMatch = strings.TrimSpace(ifMatch)
+	ifMatch = strings.TrimPrefix(ifMatch, "W/")
+	ifMatch = strings.Trim(ifMatch, `"`)
+	if ifMatch == "" {
+		return 0, false
+	}
+	v, err := strconv.Atoi(ifMatch)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,393 @@
+package resource
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/go-kafkify/rest-service/cloudevents"
+	"github.com/go-kafkify/rest-service/httplog"
+	"github.com/go-kafkify/rest-service/schemaregistry"
+	"github.com/go-kafkify/rest-service/storage"
+)
+
+// Handler serves the Resource CRUD endpoints. All of its dependencies are
+// passed in at construction instead of read from package-level globals, so a
+// Handler can be built once with a mock *sql.DB in a test, or embedded in
+// another binary (e.g. grpc-service) that wants the same REST surface.
+type Handler struct {
+	db             *sql.DB
+	logger         *zap.Logger
+	tracer         trace.Tracer
+	schemaRegistry *schemaregistry.Client
+	store          storage.ObjectStore
+	attachments    AttachmentConfig
+}
+
+// AttachmentConfig configures the attachment upload/download endpoints.
+type AttachmentConfig struct {
+	// Bucket is the bucket name recorded against each attachment row; the
+	// store itself (see storage.Config) is what actually routes to it.
+	Bucket string
+	// PresignTTL is how long a presigned PUT/GET URL remains valid.
+	PresignTTL time.Duration
+	// MaxUploadSize caps the number of bytes an upload may stream, in bytes.
+	MaxUploadSize int64
+}
+
+// New builds a Handler. schemaRegistry may be disabled (see
+// schemaregistry.Client.Enabled); it is never nil. store backs the
+// attachment endpoints.
+func New(db *sql.DB, logger *zap.Logger, tracer trace.Tracer, schemaRegistry *schemaregistry.Client, store storage.ObjectStore, attachments AttachmentConfig) *Handler {
+	return &Handler{
+		db:             db,
+		logger:         logger,
+		tracer:         tracer,
+		schemaRegistry: schemaRegistry,
+		store:          store,
+		attachments:    attachments,
+	}
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, span := h.tracer.Start(ctx, "createResource")
+	defer span.End()
+
+	var res Resource
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		httplog.FromContext(ctx).Error("failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	res.ID = uuid.New().String()
+	res.Status = "active"
+	res.Version = 1
+	res.CreatedAt = time.Now()
+	res.UpdatedAt = time.Now()
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO resources (id, name, description, status, version, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = tx.ExecContext(ctx, query, res.ID, res.Name, res.Description,
+		res.Status, res.Version, res.CreatedAt, res.UpdatedAt)
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to insert resource", "error", err)
+		http.Error(w, "Failed to create resource", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.insertOutboxEvent(ctx, tx, res.ID, "resource.created", res, 0, res.Version); err != nil {
+		httplog.FromContext(ctx).Error("failed to insert outbox event", "error", err)
+		http.Error(w, "Failed to create resource", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		httplog.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		http.Error(w, "Failed to create resource", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetAttributes(attribute.String("resource.id", res.ID))
+	httplog.FromContext(ctx).Info("resource created", "id", res.ID)
+
+	w.Header().Set("ETag", etag(res.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, span := h.tracer.Start(ctx, "listResources")
+	defer span.End()
+
+	query := `SELECT id, name, description, status, version, created_at, updated_at FROM resources ORDER BY created_at DESC LIMIT 100`
+	rows, err := h.db.QueryContext(ctx, query)
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to query resources", "error", err)
+		http.Error(w, "Failed to list resources", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	resources := []Resource{}
+	for rows.Next() {
+		var res Resource
+		if err := rows.Scan(&res.ID, &res.Name, &res.Description, &res.Status, &res.Version, &res.CreatedAt, &res.UpdatedAt); err != nil {
+			httplog.FromContext(ctx).Error("failed to scan resource", "error", err)
+			continue
+		}
+		resources = append(resources, res)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resources)
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, span := h.tracer.Start(ctx, "getResource")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var res Resource
+	query := `SELECT id, name, description, status, version, created_at, updated_at FROM resources WHERE id = $1`
+	err := h.db.QueryRowContext(ctx, query, id).Scan(
+		&res.ID, &res.Name, &res.Description, &res.Status, &res.Version,
+		&res.CreatedAt, &res.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		http.Error(w, "Resource not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to query resource", "error", err)
+		http.Error(w, "Failed to get resource", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetAttributes(attribute.String("resource.id", id))
+	w.Header().Set("ETag", etag(res.Version))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, span := h.tracer.Start(ctx, "updateResource")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var update Resource
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	update.ID = id
+	update.UpdatedAt = time.Now()
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// If-Match pins the update to the version the caller last read. Without
+	// one we read the current version ourselves, which still prevents lost
+	// updates between two concurrent writers even though it can't detect
+	// staleness against what this particular caller saw.
+	expectedVersion, hasIfMatch := versionFromIfMatch(r.Header.Get("If-Match"))
+	if !hasIfMatch {
+		if err := tx.QueryRowContext(ctx, `SELECT version FROM resources WHERE id = $1`, id).Scan(&expectedVersion); err == sql.ErrNoRows {
+			http.Error(w, "Resource not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			httplog.FromContext(ctx).Error("failed to read current version", "error", err)
+			http.Error(w, "Failed to update resource", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	query := `UPDATE resources SET name = $1, description = $2, status = $3, updated_at = $4, version = version + 1
+			  WHERE id = $5 AND version = $6
+			  RETURNING version`
+	err = tx.QueryRowContext(ctx, query, update.Name, update.Description, update.Status, update.UpdatedAt, id, expectedVersion).Scan(&update.Version)
+	if err == sql.ErrNoRows {
+		exists, existsErr := h.resourceExists(ctx, tx, id)
+		if existsErr != nil {
+			httplog.FromContext(ctx).Error("failed to check resource existence", "error", existsErr)
+			http.Error(w, "Failed to update resource", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Resource not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Resource has been modified", http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to update resource", "error", err)
+		http.Error(w, "Failed to update resource", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.insertOutboxEvent(ctx, tx, id, "resource.updated", update, expectedVersion, update.Version); err != nil {
+		httplog.FromContext(ctx).Error("failed to insert outbox event", "error", err)
+		http.Error(w, "Failed to update resource", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		httplog.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		http.Error(w, "Failed to update resource", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetAttributes(attribute.String("resource.id", id))
+	httplog.FromContext(ctx).Info("resource updated", "id", id)
+
+	w.Header().Set("ETag", etag(update.Version))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(update)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, span := h.tracer.Start(ctx, "deleteResource")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to begin transaction", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	expectedVersion, hasIfMatch := versionFromIfMatch(r.Header.Get("If-Match"))
+	if !hasIfMatch {
+		if err := tx.QueryRowContext(ctx, `SELECT version FROM resources WHERE id = $1`, id).Scan(&expectedVersion); err == sql.ErrNoRows {
+			http.Error(w, "Resource not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			httplog.FromContext(ctx).Error("failed to read current version", "error", err)
+			http.Error(w, "Failed to delete resource", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	query := `DELETE FROM resources WHERE id = $1 AND version = $2`
+	result, err := tx.ExecContext(ctx, query, id, expectedVersion)
+	if err != nil {
+		httplog.FromContext(ctx).Error("failed to delete resource", "error", err)
+		http.Error(w, "Failed to delete resource", http.StatusInternalServerError)
+		return
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		exists, existsErr := h.resourceExists(ctx, tx, id)
+		if existsErr != nil {
+			httplog.FromContext(ctx).Error("failed to check resource existence", "error", existsErr)
+			http.Error(w, "Failed to delete resource", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Resource not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Resource has been modified", http.StatusPreconditionFailed)
+		return
+	}
+
+	eventPayload := map[string]string{"id": id, "status": "deleted"}
+	if err := h.insertOutboxEvent(ctx, tx, id, "resource.deleted", eventPayload, expectedVersion, 0); err != nil {
+		httplog.FromContext(ctx).Error("failed to insert outbox event", "error", err)
+		http.Error(w, "Failed to delete resource", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		httplog.FromContext(ctx).Error("failed to commit transaction", "error", err)
+		http.Error(w, "Failed to delete resource", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetAttributes(attribute.String("resource.id", id))
+	httplog.FromContext(ctx).Info("resource deleted", "id", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
+	if err := h.db.Ping(); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "unhealthy", "error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
+}
+
+// resourceExists reports whether id still has a row, used to tell a lost
+// race (412, row exists at a different version) apart from a resource that
+// was never there or was already deleted (404).
+func (h *Handler) resourceExists(ctx context.Context, tx *sql.Tx, id string) (bool, error) {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM resources WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+// versionedPayload wraps an outbox payload with the version transition it
+// resulted from, so downstream consumers can order and deduplicate
+// redeliveries by version instead of just by arrival order.
+type versionedPayload struct {
+	Resource        interface{} `json:"resource"`
+	PreviousVersion int         `json:"previous_version"`
+	NewVersion      int         `json:"new_version"`
+}
+
+// insertOutboxEvent wraps payload (alongside its previous/new version) in a
+// CloudEvents v1.0 envelope (so every event on the bus carries a uniform set
+// of attributes regardless of which handler produced it), validates it
+// against schemaSubject's registered schema when a schema registry is
+// configured, and stores the envelope as the outbox row's payload.
+func (h *Handler) insertOutboxEvent(ctx context.Context, tx *sql.Tx, aggregateID, eventType string, payload interface{}, previousVersion, newVersion int) error {
+	ctx, span := h.tracer.Start(ctx, "insertOutboxEvent")
+	defer span.End()
+
+	eventID := uuid.New().String()
+	schemaSubject := eventType + "-value"
+
+	data := versionedPayload{Resource: payload, PreviousVersion: previousVersion, NewVersion: newVersion}
+
+	event, err := cloudevents.NewEvent(eventID, "rest-service", eventType, aggregateID, data, cloudevents.TraceparentFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to build cloudevents envelope: %w", err)
+	}
+
+	envelopeJSON, err := cloudevents.Encode(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode cloudevents envelope: %w", err)
+	}
+
+	if err := h.schemaRegistry.Validate(schemaSubject, envelopeJSON); err != nil {
+		return fmt.Errorf("schema registry: %w", err)
+	}
+
+	query := `INSERT INTO outbox_events (id, aggregate_id, event_type, payload, schema_subject, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.ExecContext(ctx, query, eventID, aggregateID, eventType, string(envelopeJSON), schemaSubject, time.Now())
+	return err
+}
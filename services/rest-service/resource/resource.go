@@ -0,0 +1,51 @@
+// Package resource implements the Resource CRUD HTTP handlers and the outbox
+// event they write alongside each change. It is factored out of the service's
+// main package so the handlers depend only on their constructor's arguments (never
+// package-level globals), which makes them usable from a test with a mock
+// *sql.DB and, per the sibling grpc-service module's needs, reusable outside
+// this binary entirely.
+package resource
+
+import (
+	"time"
+)
+
+// Resource is the domain entity the API exposes. Version backs optimistic
+// concurrency: it is surfaced as the ETag header and bumped by one on every
+// successful update.
+type Resource struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// OutboxEvent mirrors an outbox_events row.
+type OutboxEvent struct {
+	ID            string     `json:"id"`
+	AggregateID   string     `json:"aggregate_id"`
+	EventType     string     `json:"event_type"`
+	Payload       string     `json:"payload"`
+	SchemaSubject string     `json:"schema_subject"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ProcessedAt   *time.Time `json:"processed_at"`
+}
+
+// Attachment mirrors a resource_attachments row: metadata about one object a
+// client has uploaded to the configured storage.ObjectStore for a Resource.
+// The object bytes themselves never pass through this struct or the
+// process's memory beyond the streaming copy that produced SHA256.
+type Attachment struct {
+	ID          string    `json:"id"`
+	ResourceID  string    `json:"resource_id"`
+	Name        string    `json:"name"`
+	Bucket      string    `json:"bucket"`
+	ObjectKey   string    `json:"object_key"`
+	SizeBytes   int64     `json:"size_bytes"`
+	SHA256      string    `json:"sha256"`
+	ContentType string    `json:"content_type"`
+	CreatedAt   time.Time `json:"created_at"`
+}
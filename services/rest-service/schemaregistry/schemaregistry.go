@@ -0,0 +1,106 @@
+// Package schemaregistry provides optional Confluent Schema Registry
+// integration for outbox payloads: validating a payload against its
+// subject's latest schema before it is persisted, and framing an encoded
+// payload with the Confluent wire-format header (magic byte + schema ID)
+// before it is published to Kafka. Both are no-ops when SCHEMA_REGISTRY_URL
+// is unset, so the rest-service works without a registry in local/dev setups.
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/riferrei/srclient"
+)
+
+// confluentMagicByte is the leading byte of the Confluent wire format: magic
+// byte + 4-byte big-endian schema ID, followed by the encoded payload.
+const confluentMagicByte = 0x0
+
+// Client resolves and caches schemas from a Confluent Schema Registry. A nil
+// *srclient.SchemaRegistryClient (no URL configured) makes every method a
+// no-op, so callers don't need to branch on whether a registry is in use.
+type Client struct {
+	inner *srclient.SchemaRegistryClient
+}
+
+// New builds a Client for url. An empty url disables the registry: Validate
+// always succeeds and EncodeForSubject passes the payload through unchanged.
+func New(url string) *Client {
+	if url == "" {
+		return &Client{}
+	}
+	return &Client{inner: srclient.CreateSchemaRegistryClient(url)}
+}
+
+// Enabled reports whether a registry URL was configured.
+func (c *Client) Enabled() bool {
+	return c.inner != nil
+}
+
+// Validate checks that payload (JSON) is shaped like subject's latest
+// registered schema. For Avro schemas this means parsing payload through the
+// schema's codec; for every other schema type, or when no registry is
+// configured, Validate only confirms the subject's schema (if any) is
+// reachable and otherwise passes payload through unchecked.
+func (c *Client) Validate(subject string, payload []byte) error {
+	if c.inner == nil {
+		return nil
+	}
+
+	schema, err := c.inner.GetLatestSchema(subject)
+	if err != nil {
+		return fmt.Errorf("failed to resolve latest schema for subject %s: %w", subject, err)
+	}
+
+	if schema.SchemaType() != nil && *schema.SchemaType() == srclient.Avro {
+		avroCodec, err := goavro.NewCodec(schema.Schema())
+		if err != nil {
+			return fmt.Errorf("failed to build avro codec for subject %s: %w", subject, err)
+		}
+		if _, _, err := avroCodec.NativeFromTextual(payload); err != nil {
+			return fmt.Errorf("payload does not match schema for subject %s: %w", subject, err)
+		}
+	}
+
+	return nil
+}
+
+// EncodeForSubject frames payload with the Confluent wire-format header for
+// subject's latest schema: Avro schemas are re-encoded to Avro binary first;
+// every other schema type (including no registry configured) is passed
+// through as-is, with the header omitted when there is no schema to frame
+// against.
+func (c *Client) EncodeForSubject(subject string, payload []byte) ([]byte, error) {
+	if c.inner == nil {
+		return payload, nil
+	}
+
+	schema, err := c.inner.GetLatestSchema(subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve latest schema for subject %s: %w", subject, err)
+	}
+
+	body := payload
+	if schema.SchemaType() != nil && *schema.SchemaType() == srclient.Avro {
+		var native interface{}
+		if err := json.Unmarshal(payload, &native); err != nil {
+			return nil, fmt.Errorf("failed to parse payload as json: %w", err)
+		}
+		avroCodec, err := goavro.NewCodec(schema.Schema())
+		if err != nil {
+			return nil, fmt.Errorf("failed to build avro codec for subject %s: %w", subject, err)
+		}
+		body, err = avroCodec.BinaryFromNative(nil, native)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode avro payload for subject %s: %w", subject, err)
+		}
+	}
+
+	header := make([]byte, 5)
+	header[0] = confluentMagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(schema.ID()))
+	return append(header, body...), nil
+}
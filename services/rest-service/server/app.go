@@ -0,0 +1,52 @@
+// Package server assembles rest-service's dependency graph: it builds the
+// shared DB handle, logger, and tracer, and wires them into an http.Handler.
+// Each piece is built by a small NewX constructor that returns an error
+// instead of calling log.Fatal, so cmd/rest-service/main.go is the only place
+// that decides what to do when wiring fails.
+package server
+
+import (
+	"database/sql"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/go-kafkify/rest-service/httplog"
+	"github.com/go-kafkify/rest-service/outbox"
+	"github.com/go-kafkify/rest-service/resource"
+)
+
+// App holds the fully assembled dependency graph for one running instance.
+type App struct {
+	DB     *sql.DB
+	Logger *zap.Logger
+	Tracer trace.Tracer
+	Outbox *outbox.Relay
+	Router *mux.Router
+}
+
+// NewRouter wires the Resource CRUD handlers, health check, and metrics
+// endpoint behind the tracing and access-log middleware.
+func NewRouter(logger *zap.Logger, resourceHandler *resource.Handler) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(otelmux.Middleware("rest-service"))
+	router.Use(httplog.Middleware(logger))
+
+	apiRouter := router.PathPrefix("/api/v1").Subrouter()
+	apiRouter.HandleFunc("/resources", resourceHandler.Create).Methods("POST")
+	apiRouter.HandleFunc("/resources", resourceHandler.List).Methods("GET")
+	apiRouter.HandleFunc("/resources/{id}", resourceHandler.Get).Methods("GET")
+	apiRouter.HandleFunc("/resources/{id}", resourceHandler.Update).Methods("PUT")
+	apiRouter.HandleFunc("/resources/{id}", resourceHandler.Delete).Methods("DELETE")
+	apiRouter.HandleFunc("/resources/{id}/attachments", resourceHandler.UploadAttachment).Methods("POST")
+	apiRouter.HandleFunc("/resources/{id}/attachments/{name}", resourceHandler.GetAttachment).Methods("GET")
+	apiRouter.HandleFunc("/resources/{id}/attachments/{name}", resourceHandler.DeleteAttachment).Methods("DELETE")
+
+	router.HandleFunc("/health", resourceHandler.Health).Methods("GET")
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	return router
+}
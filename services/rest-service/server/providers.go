@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// NewLogger builds the production zap logger every other provider logs
+// through.
+func NewLogger() (*zap.Logger, error) {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	return logger, nil
+}
+
+// DBConfig holds the Postgres connection parameters for NewDB.
+type DBConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+}
+
+// NewDB opens the Postgres connection pool and blocks, retrying, until the
+// database answers a ping or the retry budget is exhausted. It does not
+// apply migrations/: those are intended to be run out-of-band by a migration
+// tool (e.g. golang-migrate) as a deploy step ahead of rolling out a new
+// binary, the same as grpc-service's migrations/. Nothing in this repo
+// wires a runner in-process.
+func NewDB(ctx context.Context, cfg DBConfig, logger *zap.Logger) (*sql.DB, error) {
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	for i := 0; i < 30; i++ {
+		if err := db.PingContext(ctx); err == nil {
+			logger.Info("Database connection established")
+			return db, nil
+		}
+		logger.Info("Waiting for database...", zap.Int("attempt", i+1))
+		time.Sleep(2 * time.Second)
+	}
+
+	return nil, fmt.Errorf("failed to connect to database after 30 attempts")
+}
+
+// NewTracer builds the OTel tracer provider for serviceName and registers it
+// globally. The returned shutdown func must be called to flush pending spans.
+func NewTracer(ctx context.Context, serviceName string) (trace.Tracer, func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build otel resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Tracer(serviceName), tp.Shutdown, nil
+}
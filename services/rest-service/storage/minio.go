@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Config holds the connection parameters for a MinIO/S3-compatible endpoint.
+type Config struct {
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	UseSSL    bool
+}
+
+// MinIOStore is an ObjectStore backed by a MinIO or AWS S3 bucket.
+type MinIOStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewMinIOStore builds a MinIOStore for cfg.Bucket. It does not verify the
+// bucket exists; a misconfigured endpoint or bucket only surfaces on first
+// use.
+func NewMinIOStore(cfg Config) (*MinIOStore, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build minio client: %w", err)
+	}
+	return &MinIOStore{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *MinIOStore) PresignPut(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	u, err := s.client.PresignedPutObject(ctx, s.bucket, key, ttl)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign put for %s: %w", key, err)
+	}
+	return u.String(), nil
+}
+
+func (s *MinIOStore) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, ttl, url.Values{})
+	if err != nil {
+		return "", fmt.Errorf("failed to presign get for %s: %w", key, err)
+	}
+	return u.String(), nil
+}
+
+func (s *MinIOStore) Remove(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+// Package storage provides the object-storage abstraction backing Resource
+// attachments. rest-service never buffers a whole object in memory: callers
+// stream bytes straight through to the backend (spooling to disk only if
+// the transport requires a known Content-Length) and hand clients presigned
+// URLs for the actual GET, so the service itself is never in the data path
+// for a download.
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// ObjectStore issues presigned URLs for an S3-compatible bucket and removes
+// objects that are no longer referenced. MinIOStore is the only
+// implementation today, but the interface is what resource.Handler depends
+// on so a test can substitute a fake.
+type ObjectStore interface {
+	// PresignPut returns a URL the caller can PUT an object's bytes to
+	// directly, valid for ttl.
+	PresignPut(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// PresignGet returns a URL the caller can GET an object's bytes from
+	// directly, valid for ttl.
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// Remove deletes the object at key. It is not an error if key does not exist.
+	Remove(ctx context.Context, key string) error
+}